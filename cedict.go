@@ -58,13 +58,22 @@ type CEDict struct {
 	entry     *Entry
 }
 
-// Entry represents a single entry in the cedict dictionary.
+// Entry represents a single entry in the cedict dictionary. Pinyin preserves
+// CEDICT's original capitalization, which marks proper nouns by capitalizing
+// the first letter of each relevant syllable (e.g. "Lian2 zhou1" for 连州);
+// PinyinLower holds the all-lowercase form, and IsProperNoun reports whether
+// any syllable was capitalized.
 type Entry struct {
 	Simplified      string
 	Traditional     string
 	Pinyin          string
+	PinyinLower     string
+	IsProperNoun    bool
 	PinyinWithTones string
 	PinyinNoTones   string
+	Zhuyin          string
+	Syllables       []Syllable
+	Frequency       int
 	Definitions     []string
 }
 
@@ -112,6 +121,9 @@ func New(r io.Reader) *CEDict {
 	}
 	// splitFunc defines how we split our tokens
 	splitFunc := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
 		if data[0] == '#' {
 			advance, token, err = consumeComment(data, atEOF)
 			c.TokenType = CommentToken
@@ -129,7 +141,7 @@ func New(r io.Reader) *CEDict {
 // its appropriate UTF-8 character with tone marks
 func toneLookupTable(tone int) (map[string]string, error) {
 	if tone < 0 || tone > 5 {
-		return nil, fmt.Errorf("Tried to create tone lookup table with tone %i", tone)
+		return nil, fmt.Errorf("Tried to create tone lookup table with tone %d", tone)
 	}
 
 	lookupTable := map[string][]string{
@@ -144,7 +156,9 @@ func toneLookupTable(tone int) (map[string]string, error) {
 	toneLookup := make(map[string]string)
 
 	for vowel, toneRunes := range lookupTable {
-		toneLookup[vowel] = toneRunes[tone]
+		marked := toneRunes[tone]
+		toneLookup[vowel] = marked
+		toneLookup[strings.ToUpper(vowel)] = strings.ToUpper(marked)
 	}
 
 	return toneLookup, nil
@@ -162,40 +176,47 @@ func extractTone(p string) (string, int) {
 }
 
 // replaceWithToneMark returns the UTF-8 representation of a pinyin syllable with
-// the appropriate tone, e.g., dong1 => dōng, using the pinyin accent placement rules
+// the appropriate tone, e.g., dong1 => dōng, using the pinyin accent placement rules.
+// Matching is case-insensitive, and the marked vowel keeps whatever case it had in
+// s, so a capitalized proper-noun syllable such as "An1" is marked as "Ān".
 func replaceWithToneMark(s string, tone int) (string, error) {
 	lookup, err := toneLookupTable(tone)
 	if err != nil {
 		return "", err
 	}
 
-	if strings.Contains(s, "a") {
-		return strings.Replace(s, "a", lookup["a"], -1), nil
-	}
-	if strings.Contains(s, "e") {
-		return strings.Replace(s, "e", lookup["e"], -1), nil
+	lower := strings.ToLower(s)
+	index := -1
+	switch {
+	case strings.Contains(lower, "a"):
+		index = strings.Index(lower, "a")
+	case strings.Contains(lower, "e"):
+		index = strings.Index(lower, "e")
+	case strings.Contains(lower, "ou"):
+		index = strings.Index(lower, "o")
+	default:
+		index = strings.LastIndexAny(lower, "iüou")
 	}
-	if strings.Contains(s, "ou") {
-		return strings.Replace(s, "o", lookup["o"], -1), nil
+	if index == -1 {
+		return "", fmt.Errorf("No tone match")
 	}
-	index := strings.LastIndexAny(s, "iüou")
-	if index != -1 {
-		var out bytes.Buffer
-		for ind, runeValue := range s {
-			if ind == index {
-				out.WriteString(lookup[string(runeValue)])
-			} else {
-				out.WriteString(string(runeValue))
-			}
+
+	var out bytes.Buffer
+	for ind, runeValue := range s {
+		if ind == index {
+			out.WriteString(lookup[string(runeValue)])
+		} else {
+			out.WriteString(string(runeValue))
 		}
-		return out.String(), nil
 	}
-	return "", fmt.Errorf("No tone match")
+	return out.String(), nil
 }
 
-// convertToTones takes a CEDICT pinyin representation and returns the concatenated
-// pinyin version with tone marks, e.g., yi1 lan3 zi5 => yīlǎnzi
-func convertToTones(p string) string {
+// ToPinyinTonemarks takes a CEDICT pinyin representation and returns the concatenated
+// pinyin version with tone marks, e.g., yi1 lan3 zi5 => yīlǎnzi. A token that
+// doesn't carry a tone mark, such as the "," separating clauses in some idiom
+// entries, is passed through unchanged rather than aborting the conversion.
+func ToPinyinTonemarks(p string) string {
 	pv := strings.Replace(p, "u:", "ü", -1)
 	py := strings.Split(pv, " ")
 
@@ -204,7 +225,8 @@ func convertToTones(p string) string {
 		pyNoTone, tone := extractTone(pySyllable)
 		pyWithTone, err := replaceWithToneMark(pyNoTone, tone)
 		if err != nil {
-			return ""
+			out.WriteString(pyNoTone)
+			continue
 		}
 		out.WriteString(pyWithTone)
 	}
@@ -215,9 +237,10 @@ func convertToTones(p string) string {
 // pinyin version without tone marks, e.g., yi1 lan3 zi5 => yilanzi
 // This representation is useful for building a search interface to the CEDICT database
 // for user pinyin input.
-// Note: This substitutes the more common search term "v" for "ü"
+// Note: This substitutes the more common search term "v" for "ü", and lower-cases
+// the result, since proper-noun capitalization isn't meaningful to a search query.
 func pinyinNoTones(p string) string {
-	pv := strings.Replace(p, "u:", "v", -1)
+	pv := strings.ToLower(strings.Replace(p, "u:", "v", -1))
 	py := strings.Split(pv, " ")
 
 	var out bytes.Buffer
@@ -230,6 +253,18 @@ func pinyinNoTones(p string) string {
 
 var reEntry = regexp.MustCompile(`(?P<trad>\S*?) (?P<simp>\S*?) \[(?P<pinyin>.+)\] \/(?P<defs>.+)\/`)
 
+// isProperNounPinyin reports whether any space-separated syllable in p
+// starts with an uppercase letter, CEDICT's convention for flagging the
+// word as a proper noun.
+func isProperNounPinyin(p string) bool {
+	for _, syllable := range strings.Split(p, " ") {
+		if syllable != "" && syllable[0] >= 'A' && syllable[0] <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
 // parseEntry parses string entries from CEDict of the form:
 //     一之為甚 一之为甚 [yi1 zhi1 wei2 shen4] /Once is enough (idiom)/
 // It returns a pointer to a new Entry struct.
@@ -251,13 +286,17 @@ func parseEntry(s string) (*Entry, error) {
 		case "trad":
 			e.Traditional = match[i]
 		case "pinyin":
-			e.Pinyin = strings.ToLower(match[i])
+			e.Pinyin = match[i]
 		case "defs":
 			e.Definitions = strings.Split(match[i], "/")
 		}
 	}
-	e.PinyinWithTones = convertToTones(e.Pinyin)
+	e.PinyinLower = strings.ToLower(e.Pinyin)
+	e.IsProperNoun = isProperNounPinyin(e.Pinyin)
+	e.PinyinWithTones = ToPinyinTonemarks(e.Pinyin)
 	e.PinyinNoTones = pinyinNoTones(e.Pinyin)
+	e.Zhuyin = ToZhuyin(e.Pinyin)
+	e.Syllables = ParsePinyin(e.Pinyin)
 	return &e, nil
 }
 
@@ -284,6 +323,22 @@ func (c *CEDict) NextEntry() error {
 	return NoMoreEntries
 }
 
+// NextEntryFiltered behaves like NextEntry, but skips over entries that
+// don't satisfy pred, e.g. to omit entries below a frequency threshold or
+// in an unwanted category. It returns NoMoreEntries once the underlying
+// CEDict is exhausted without producing a match.
+func (c *CEDict) NextEntryFiltered(pred func(*Entry) bool) error {
+	for {
+		err := c.NextEntry()
+		if err != nil {
+			return err
+		}
+		if pred(c.Entry()) {
+			return nil
+		}
+	}
+}
+
 // Entry returns a pointer to the most recently parsed Entry struct.
 func (c *CEDict) Entry() *Entry {
 	return c.entry
@@ -50,9 +50,17 @@ func TestParseEntry(t *testing.T) {
 				Simplified:      "一之为甚",
 				Traditional:     "一之為甚",
 				Pinyin:          "yi1 zhi1 wei2 shen4",
+				PinyinLower:     "yi1 zhi1 wei2 shen4",
 				PinyinWithTones: "yīzhīwéishèn",
 				PinyinNoTones:   "yizhiweishen",
-				Definitions:     []string{"Once is enough (idiom)"},
+				Zhuyin:          "ㄧ ㄓ ㄨㄟˊ ㄕㄣˋ",
+				Syllables: []Syllable{
+					{Initial: "", Final: "yi", Tone: 1},
+					{Initial: "zh", Final: "i", Tone: 1},
+					{Initial: "", Final: "wei", Tone: 2},
+					{Initial: "sh", Final: "en", Tone: 4},
+				},
+				Definitions: []string{"Once is enough (idiom)"},
 			},
 		},
 		{
@@ -61,9 +69,15 @@ func TestParseEntry(t *testing.T) {
 				Simplified:      "一壁",
 				Traditional:     "一壁",
 				Pinyin:          "yi1 bi4",
+				PinyinLower:     "yi1 bi4",
 				PinyinWithTones: "yībì",
 				PinyinNoTones:   "yibi",
-				Definitions:     []string{"one side", "at the same time"},
+				Zhuyin:          "ㄧ ㄅㄧˋ",
+				Syllables: []Syllable{
+					{Initial: "", Final: "yi", Tone: 1},
+					{Initial: "b", Final: "i", Tone: 4},
+				},
+				Definitions: []string{"one side", "at the same time"},
 			},
 		},
 		{
@@ -72,9 +86,38 @@ func TestParseEntry(t *testing.T) {
 				Simplified:      "眼不见，心不烦",
 				Traditional:     "眼不見，心不煩",
 				Pinyin:          "yan3 bu4 jian4 , xin1 bu4 fan2",
+				PinyinLower:     "yan3 bu4 jian4 , xin1 bu4 fan2",
 				PinyinWithTones: "yǎnbùjiàn,xīnbùfán",
 				PinyinNoTones:   "yanbujian,xinbufan",
-				Definitions:     []string{"what the eye doesn't see, the heart doesn't grieve over (idiom)"},
+				Zhuyin:          "ㄧㄢˇ ㄅㄨˋ ㄐㄧㄢˋ , ㄒㄧㄣ ㄅㄨˋ ㄈㄢˊ",
+				Syllables: []Syllable{
+					{Initial: "", Final: "yan", Tone: 3},
+					{Initial: "b", Final: "u", Tone: 4},
+					{Initial: "j", Final: "ian", Tone: 4},
+					{Initial: "", Final: ",", Tone: 0},
+					{Initial: "x", Final: "in", Tone: 1},
+					{Initial: "b", Final: "u", Tone: 4},
+					{Initial: "f", Final: "an", Tone: 2},
+				},
+				Definitions: []string{"what the eye doesn't see, the heart doesn't grieve over (idiom)"},
+			},
+		},
+		{
+			give: "連州 连州 [Lian2 zhou1] /Lianzhou, county-level city in Qingyuan 清遠|清远, Guangdong/",
+			want: Entry{
+				Simplified:      "连州",
+				Traditional:     "連州",
+				Pinyin:          "Lian2 zhou1",
+				PinyinLower:     "lian2 zhou1",
+				IsProperNoun:    true,
+				PinyinWithTones: "Liánzhōu",
+				PinyinNoTones:   "lianzhou",
+				Zhuyin:          "ㄌㄧㄢˊ ㄓㄡ",
+				Syllables: []Syllable{
+					{Initial: "L", Final: "ian", Tone: 2},
+					{Initial: "zh", Final: "ou", Tone: 1},
+				},
+				Definitions: []string{"Lianzhou, county-level city in Qingyuan 清遠|清远, Guangdong"},
 			},
 		},
 	}
@@ -100,11 +143,30 @@ func TestCEDict(t *testing.T) {
 一攬子 一揽子 [yi1 lan3 zi5] /all-inclusive/undiscriminating/
 一東一西 一东一西 [yi1 dong1 yi1 xi1] /far apart/`
 	want := []Entry{
-		{Simplified: "一团火", Traditional: "一團火", Pinyin: "yi1 tuan2 huo3", PinyinWithTones: "yītuánhuǒ", PinyinNoTones: "yituanhuo", Definitions: []string{"fireball", "ball of fire"}},
-		{Simplified: "一团", Traditional: "一團", Pinyin: "yi1 tuan2", PinyinWithTones: "yītuán", PinyinNoTones: "yituan", Definitions: []string{"1 regiment"}},
-		{Simplified: "一层", Traditional: "一層", Pinyin: "yi1 ceng2", PinyinWithTones: "yīcéng", PinyinNoTones: "yiceng", Definitions: []string{"layer"}},
-		{Simplified: "一揽子", Traditional: "一攬子", Pinyin: "yi1 lan3 zi5", PinyinWithTones: "yīlǎnzi", PinyinNoTones: "yilanzi", Definitions: []string{"all-inclusive", "undiscriminating"}},
-		{Simplified: "一东一西", Traditional: "一東一西", Pinyin: "yi1 dong1 yi1 xi1", PinyinWithTones: "yīdōngyīxī", PinyinNoTones: "yidongyixi", Definitions: []string{"far apart"}},
+		{Simplified: "一团火", Traditional: "一團火", Pinyin: "yi1 tuan2 huo3", PinyinLower: "yi1 tuan2 huo3", PinyinWithTones: "yītuánhuǒ", PinyinNoTones: "yituanhuo", Zhuyin: "ㄧ ㄊㄨㄢˊ ㄏㄨㄛˇ", Syllables: []Syllable{
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "t", Final: "uan", Tone: 2},
+			{Initial: "h", Final: "uo", Tone: 3},
+		}, Definitions: []string{"fireball", "ball of fire"}},
+		{Simplified: "一团", Traditional: "一團", Pinyin: "yi1 tuan2", PinyinLower: "yi1 tuan2", PinyinWithTones: "yītuán", PinyinNoTones: "yituan", Zhuyin: "ㄧ ㄊㄨㄢˊ", Syllables: []Syllable{
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "t", Final: "uan", Tone: 2},
+		}, Definitions: []string{"1 regiment"}},
+		{Simplified: "一层", Traditional: "一層", Pinyin: "yi1 ceng2", PinyinLower: "yi1 ceng2", PinyinWithTones: "yīcéng", PinyinNoTones: "yiceng", Zhuyin: "ㄧ ㄘㄥˊ", Syllables: []Syllable{
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "c", Final: "eng", Tone: 2},
+		}, Definitions: []string{"layer"}},
+		{Simplified: "一揽子", Traditional: "一攬子", Pinyin: "yi1 lan3 zi5", PinyinLower: "yi1 lan3 zi5", PinyinWithTones: "yīlǎnzi", PinyinNoTones: "yilanzi", Zhuyin: "ㄧ ㄌㄢˇ ˙ㄗ", Syllables: []Syllable{
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "l", Final: "an", Tone: 3},
+			{Initial: "z", Final: "i", Tone: 5},
+		}, Definitions: []string{"all-inclusive", "undiscriminating"}},
+		{Simplified: "一东一西", Traditional: "一東一西", Pinyin: "yi1 dong1 yi1 xi1", PinyinLower: "yi1 dong1 yi1 xi1", PinyinWithTones: "yīdōngyīxī", PinyinNoTones: "yidongyixi", Zhuyin: "ㄧ ㄉㄨㄥ ㄧ ㄒㄧ", Syllables: []Syllable{
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "d", Final: "ong", Tone: 1},
+			{Initial: "", Final: "yi", Tone: 1},
+			{Initial: "x", Final: "i", Tone: 1},
+		}, Definitions: []string{"far apart"}},
 	}
 	r := io.Reader(strings.NewReader(raw))
 	c := New(r)
@@ -131,6 +193,221 @@ func TestCEDict(t *testing.T) {
 	}
 }
 
+// TestIndexTokenize builds a small Index from a handful of entries and
+// checks that Tokenize greedily matches the longest known headword at
+// each position, falling back to single-rune tokens for unknown text.
+func TestIndexTokenize(t *testing.T) {
+	raw := `一層 一层 [yi1 ceng2] /layer/
+一團 一团 [yi1 tuan2] /1 regiment/
+一團火 一团火 [yi1 tuan2 huo3] /fireball/ball of fire/`
+	idx, err := NewIndex(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("NewIndex() error: %v", err)
+	}
+
+	if e := idx.Lookup("一团"); e == nil || e.Definitions[0] != "1 regiment" {
+		t.Errorf("Lookup(%q) = %v, want entry for \"1 regiment\"", "一团", e)
+	}
+	if e := idx.Lookup("一"); e != nil {
+		t.Errorf("Lookup(%q) = %v, want nil", "一", e)
+	}
+
+	tokens := idx.Tokenize("一团火爆")
+	want := []string{"一团火", "爆"}
+	if len(tokens) != len(want) {
+		t.Fatalf("Tokenize() = %v, want %d tokens matching %v", tokens, len(want), want)
+	}
+	for i, tok := range tokens {
+		if tok.Text != want[i] {
+			t.Errorf("Tokenize()[%d].Text = %q, want %q", i, tok.Text, want[i])
+		}
+	}
+	if tokens[0].Entry == nil || tokens[0].Entry.Definitions[0] != "fireball" {
+		t.Errorf("Tokenize()[0].Entry = %v, want entry for \"fireball\"", tokens[0].Entry)
+	}
+	if tokens[1].Entry != nil {
+		t.Errorf("Tokenize()[1].Entry = %v, want nil", tokens[1].Entry)
+	}
+}
+
+// TestLoadFrequencies checks parsing of whitespace-separated "word count"
+// frequency lines, and that Count looks counts back up by word.
+func TestLoadFrequencies(t *testing.T) {
+	raw := `的 58197
+了 18578
+
+是 12345`
+	ft, err := LoadFrequencies(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("LoadFrequencies() error: %v", err)
+	}
+	if got := ft.Count("的"); got != 58197 {
+		t.Errorf("Count(%q) = %d, want %d", "的", got, 58197)
+	}
+	if got := ft.Count("没见过"); got != 0 {
+		t.Errorf("Count(%q) = %d, want 0", "没见过", got)
+	}
+	if _, err := LoadFrequencies(strings.NewReader("的\n")); err == nil {
+		t.Error("LoadFrequencies() with a missing count expected an error, got nil")
+	}
+}
+
+// TestIndexWithFrequencies checks that building an Index WithFrequencies
+// populates each Entry's Frequency field from the table.
+func TestIndexWithFrequencies(t *testing.T) {
+	raw := "一層 一层 [yi1 ceng2] /layer/"
+	ft, err := LoadFrequencies(strings.NewReader("一层 42"))
+	if err != nil {
+		t.Fatalf("LoadFrequencies() error: %v", err)
+	}
+	idx, err := NewIndex(strings.NewReader(raw), WithFrequencies(ft))
+	if err != nil {
+		t.Fatalf("NewIndex() error: %v", err)
+	}
+	if e := idx.Lookup("一层"); e == nil || e.Frequency != 42 {
+		t.Errorf("Lookup(%q).Frequency = %v, want 42", "一层", e)
+	}
+}
+
+// TestIndexRankedLookup checks that RankedLookup ranks homograph entries
+// of the same word by descending Frequency.
+func TestIndexRankedLookup(t *testing.T) {
+	raw := `行 行 [hang2] /row/line/
+行 行 [xing2] /to walk/to go/to travel/`
+	idx, err := NewIndex(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("NewIndex() error: %v", err)
+	}
+	for _, e := range idx.RankedLookup("行") {
+		if e.Pinyin == "xing2" {
+			e.Frequency = 500
+		} else {
+			e.Frequency = 10
+		}
+	}
+
+	entries := idx.RankedLookup("行")
+	if len(entries) != 2 {
+		t.Fatalf("RankedLookup(%q) = %v, want 2 entries", "行", entries)
+	}
+	if entries[0].Pinyin != "xing2" || entries[1].Pinyin != "hang2" {
+		t.Errorf("RankedLookup(%q) = %+v, want xing2 before hang2", "行", entries)
+	}
+}
+
+// TestNextEntryFiltered checks that NextEntryFiltered skips entries that
+// don't satisfy the predicate.
+func TestNextEntryFiltered(t *testing.T) {
+	raw := `一層 一层 [yi1 ceng2] /layer/
+一團 一团 [yi1 tuan2] /1 regiment/`
+	c := New(strings.NewReader(raw))
+	err := c.NextEntryFiltered(func(e *Entry) bool {
+		return e.Simplified == "一团"
+	})
+	if err != nil {
+		t.Fatalf("NextEntryFiltered() error: %v", err)
+	}
+	if c.Entry().Simplified != "一团" {
+		t.Errorf("NextEntryFiltered() entry = %+v, want Simplified \"一团\"", c.Entry())
+	}
+}
+
+// TestParsePinyin checks that ParsePinyin splits a CEDICT pinyin string
+// into Syllables, and that Marked/Numbered round-trip through the usual
+// tone-mark and numbered pinyin representations.
+func TestParsePinyin(t *testing.T) {
+	syllables := ParsePinyin("zhong1 guo2 ren2")
+	want := []Syllable{
+		{Initial: "zh", Final: "ong", Tone: 1},
+		{Initial: "g", Final: "uo", Tone: 2},
+		{Initial: "r", Final: "en", Tone: 2},
+	}
+	if !reflect.DeepEqual(syllables, want) {
+		t.Fatalf("ParsePinyin() = %#v, want %#v", syllables, want)
+	}
+
+	markedWant := []string{"zhōng", "guó", "rén"}
+	numberedWant := []string{"zhong1", "guo2", "ren2"}
+	for i, s := range syllables {
+		if got := s.Marked(); got != markedWant[i] {
+			t.Errorf("Syllable(%+v).Marked() = %q, want %q", s, got, markedWant[i])
+		}
+		if got := s.Numbered(); got != numberedWant[i] {
+			t.Errorf("Syllable(%+v).Numbered() = %q, want %q", s, got, numberedWant[i])
+		}
+	}
+}
+
+// TestParsePinyinProperNoun checks that a capitalized, zero-initial syllable
+// keeps its capital letter through ParsePinyin and has it carried onto the
+// tone-marked vowel by Marked, e.g. the surname/place syllable "An1".
+func TestParsePinyinProperNoun(t *testing.T) {
+	syllables := ParsePinyin("An1 hui1")
+	want := []Syllable{
+		{Initial: "", Final: "An", Tone: 1},
+		{Initial: "h", Final: "ui", Tone: 1},
+	}
+	if !reflect.DeepEqual(syllables, want) {
+		t.Fatalf("ParsePinyin() = %#v, want %#v", syllables, want)
+	}
+	if got := syllables[0].Marked(); got != "Ān" {
+		t.Errorf("Syllable(%+v).Marked() = %q, want %q", syllables[0], got, "Ān")
+	}
+}
+
+// TestFromPinyinTonemarks checks that user-typed accented pinyin is
+// segmented and converted back into CEDICT's numbered form, including
+// multi-syllable words with no spaces and the neutral (unmarked) tone.
+func TestFromPinyinTonemarks(t *testing.T) {
+	tests := []struct {
+		marked string
+		want   string
+	}{
+		{marked: "yīlǎnzi", want: "yi1 lan3 zi5"},
+		{marked: "nǚ hái", want: "nu:3 hai2"},
+		{marked: "zhōngguó", want: "zhong1 guo2"},
+	}
+	for _, tt := range tests {
+		got, err := FromPinyinTonemarks(tt.marked)
+		if err != nil {
+			t.Fatalf("FromPinyinTonemarks(%q) error: %v", tt.marked, err)
+		}
+		if got != tt.want {
+			t.Errorf("FromPinyinTonemarks(%q) = %q, want %q", tt.marked, got, tt.want)
+		}
+	}
+}
+
+func TestFromPinyinTonemarksError(t *testing.T) {
+	if _, err := FromPinyinTonemarks("xyzzy"); err == nil {
+		t.Errorf("FromPinyinTonemarks(%q) expected error, got nil", "xyzzy")
+	}
+}
+
+// TestToZhuyin checks conversion of numbered pinyin to Zhuyin, including
+// the zero-initial glide spellings, the j/q/x "u means ü" convention, the
+// unwritten buzzed final after zh/ch/sh/r/z/c/s, and the neutral tone.
+func TestToZhuyin(t *testing.T) {
+	tests := []struct {
+		pinyin string
+		want   string
+	}{
+		{pinyin: "zhong1 guo2", want: "ㄓㄨㄥ ㄍㄨㄛˊ"},
+		{pinyin: "xue2 xi2", want: "ㄒㄩㄝˊ ㄒㄧˊ"},
+		{pinyin: "zhi1", want: "ㄓ"},
+		{pinyin: "yi1 lan3 zi5", want: "ㄧ ㄌㄢˇ ˙ㄗ"},
+	}
+	for _, tt := range tests {
+		got := ToZhuyin(tt.pinyin)
+		if got != tt.want {
+			t.Errorf("ToZhuyin(%q) = %q, want %q", tt.pinyin, got, tt.want)
+		}
+		if back := ZhuyinToPinyin(got); back != strings.Replace(tt.pinyin, "u:", "ü", -1) {
+			t.Errorf("ZhuyinToPinyin(%q) = %q, want %q", got, back, tt.pinyin)
+		}
+	}
+}
+
 func TestToPinyinTonemarks(t *testing.T) {
 	tests := []struct {
 		s    string
@@ -0,0 +1,52 @@
+// Copyright 2014 Herman Schaaf. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedict
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FrequencyTable holds word occurrence counts loaded by LoadFrequencies,
+// for ranking dictionary homographs by how common they are.
+type FrequencyTable struct {
+	counts map[string]int
+}
+
+// LoadFrequencies parses a whitespace-separated "word count" list, one
+// entry per line, compatible with common Chinese corpus frequency files.
+// Blank lines are skipped.
+func LoadFrequencies(r io.Reader) (*FrequencyTable, error) {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed frequency line: %q", line)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed frequency line %q: %v", line, err)
+		}
+		counts[fields[0]] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &FrequencyTable{counts: counts}, nil
+}
+
+// Count returns the occurrence count recorded for word, or 0 if word
+// doesn't appear in the table.
+func (ft *FrequencyTable) Count(word string) int {
+	return ft.counts[word]
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 Herman Schaaf. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedict
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// toneMarkRunes maps each accented pinyin vowel to the plain vowel it
+// decorates and the tone number it represents.
+var toneMarkRunes = map[rune]struct {
+	base rune
+	tone int
+}{
+	'ā': {'a', 1}, 'á': {'a', 2}, 'ǎ': {'a', 3}, 'à': {'a', 4},
+	'ē': {'e', 1}, 'é': {'e', 2}, 'ě': {'e', 3}, 'è': {'e', 4},
+	'ī': {'i', 1}, 'í': {'i', 2}, 'ǐ': {'i', 3}, 'ì': {'i', 4},
+	'ō': {'o', 1}, 'ó': {'o', 2}, 'ǒ': {'o', 3}, 'ò': {'o', 4},
+	'ū': {'u', 1}, 'ú': {'u', 2}, 'ǔ': {'u', 3}, 'ù': {'u', 4},
+	'ǖ': {'ü', 1}, 'ǘ': {'ü', 2}, 'ǚ': {'ü', 3}, 'ǜ': {'ü', 4},
+}
+
+// maxSyllableRunes bounds the maximal-munch scan in segmentSyllables: no
+// initial+final pinyin spelling is longer than this (e.g. "zhuang").
+const maxSyllableRunes = 6
+
+// stripToneMarks removes tone diacritics from a word, returning the plain
+// (but still ü-aware) spelling and the tone each diacritic indicated,
+// keyed by the rune index into the returned plain spelling.
+func stripToneMarks(word string) (plain []rune, tones map[int]int) {
+	tones = make(map[int]int)
+	for _, r := range word {
+		if tm, ok := toneMarkRunes[r]; ok {
+			tones[len(plain)] = tm.tone
+			plain = append(plain, tm.base)
+			continue
+		}
+		plain = append(plain, r)
+	}
+	return plain, tones
+}
+
+// isValidPinyinSyllable reports whether s (with no tone digit or mark) is
+// a spelling this package recognizes as a Mandarin pinyin syllable.
+func isValidPinyinSyllable(s string) bool {
+	if s == "" {
+		return false
+	}
+	initial, final := splitInitial(s)
+	initial, final = strings.ToLower(initial), strings.ToLower(final)
+	if final == "" {
+		return false
+	}
+	if initial != "" {
+		if _, ok := initialToZhuyin[initial]; !ok {
+			return false
+		}
+	}
+	_, ok := finalToZhuyin[expandFinal(initial, final)]
+	return ok
+}
+
+// segmentSyllables performs a maximal-munch scan over plain, splitting it
+// into the longest sequence of recognized pinyin syllables at each
+// position, and attaches the tone recorded for each syllable in tones
+// (defaulting to the neutral tone 5 when a syllable carries no mark).
+func segmentSyllables(plain []rune, tones map[int]int) ([]string, error) {
+	var out []string
+	for i := 0; i < len(plain); {
+		matched := false
+		max := maxSyllableRunes
+		if len(plain)-i < max {
+			max = len(plain) - i
+		}
+		for l := max; l >= 1; l-- {
+			cand := string(plain[i : i+l])
+			if !isValidPinyinSyllable(cand) {
+				continue
+			}
+			tone := 5
+			for idx, t := range tones {
+				if idx >= i && idx < i+l {
+					tone = t
+				}
+			}
+			initial, final := splitInitial(cand)
+			final = strings.Replace(final, "ü", "u:", -1)
+			out = append(out, initial+final+strconv.Itoa(tone))
+			i += l
+			matched = true
+			break
+		}
+		if !matched {
+			return nil, fmt.Errorf("cannot segment pinyin at %q", string(plain[i:]))
+		}
+	}
+	return out, nil
+}
+
+// FromPinyinTonemarks converts user-typed pinyin with tone diacritics,
+// e.g. "yīlǎnzi" or "nǚ hái", into its CEDICT-canonical numbered form,
+// e.g. "yi1 lan3 zi5" or "nu:3 hai2". Whitespace-separated words are
+// segmented into syllables independently using a maximal-munch scan over
+// the initial+final combinations this package recognizes; "ü" is rewritten
+// to "u:" as CEDICT expects. A syllable with no tone mark at all (including
+// a bare "ü" with no diacritic) is taken to carry the neutral tone 5. It
+// returns an error if any word cannot be fully segmented into valid
+// syllables.
+func FromPinyinTonemarks(marked string) (string, error) {
+	words := strings.Fields(marked)
+	syllables := make([]string, 0, len(words))
+	for _, word := range words {
+		plain, tones := stripToneMarks(word)
+		wordSyllables, err := segmentSyllables(plain, tones)
+		if err != nil {
+			return "", err
+		}
+		syllables = append(syllables, wordSyllables...)
+	}
+	return strings.Join(syllables, " "), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Herman Schaaf. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedict
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Syllable is a structured representation of a single pinyin syllable,
+// split into its Mandarin initial, final and tone (0-5, where 5 denotes
+// the neutral tone).
+type Syllable struct {
+	Initial string
+	Final   string
+	Tone    int
+}
+
+// ParsePinyin splits a CEDICT pinyin string, e.g. "zhong1 guo2", into a
+// slice of Syllables, one per space-separated syllable. Each syllable's
+// trailing tone digit is stripped into Tone, "u:" is rewritten to "ü", and
+// the remainder is split into Initial and Final.
+func ParsePinyin(cedictPinyin string) []Syllable {
+	pv := strings.Replace(cedictPinyin, "u:", "ü", -1)
+	parts := strings.Split(pv, " ")
+
+	syllables := make([]Syllable, len(parts))
+	for i, part := range parts {
+		noTone, tone := extractTone(part)
+		initial, final := splitInitial(noTone)
+		syllables[i] = Syllable{Initial: initial, Final: final, Tone: tone}
+	}
+	return syllables
+}
+
+// Marked returns the syllable's tone-marked form, e.g. "lǎn" for
+// Syllable{Initial: "l", Final: "an", Tone: 3}, using the same accent
+// placement rules as ToPinyinTonemarks. If the final doesn't contain a
+// vowel that can carry a tone mark, the unmarked form is returned.
+func (s Syllable) Marked() string {
+	full := s.Initial + s.Final
+	marked, err := replaceWithToneMark(full, s.Tone)
+	if err != nil {
+		return full
+	}
+	return marked
+}
+
+// Numbered returns the syllable's canonical CEDICT numbered form, e.g.
+// "lan3" for Syllable{Initial: "l", Final: "an", Tone: 3}. A "ü" in the
+// final is written back as "u:", e.g. "nu:3".
+func (s Syllable) Numbered() string {
+	return s.Initial + strings.Replace(s.Final, "ü", "u:", -1) + strconv.Itoa(s.Tone)
+}
@@ -0,0 +1,174 @@
+// Copyright 2014 Herman Schaaf. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedict
+
+import (
+	"io"
+	"sort"
+)
+
+// Token represents a single segment of tokenized text. If the segment
+// matched a dictionary entry, Entry points to it; otherwise Entry is nil
+// and Text holds a single, unrecognized rune.
+type Token struct {
+	Text  string
+	Entry *Entry
+}
+
+// trieNode is a single node in the Index trie. Each edge out of a node is
+// keyed on a single rune, and a node holds one entry per homograph if the
+// path from the root to that node spells out a dictionary headword.
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  []*Entry
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode)}
+}
+
+// Index is an in-memory trie of CEDict entries, keyed rune-by-rune on their
+// Simplified and Traditional headwords. It supports exact lookups as well
+// as longest-prefix-match tokenization of arbitrary Chinese text.
+type Index struct {
+	root        *trieNode
+	frequencies *FrequencyTable
+}
+
+// IndexOption configures optional behavior when building an Index with
+// NewIndex.
+type IndexOption func(*Index)
+
+// WithFrequencies attaches a FrequencyTable to the Index being built, so
+// that every inserted Entry has its Frequency field populated and
+// RankedLookup can rank homographs by it.
+func WithFrequencies(ft *FrequencyTable) IndexOption {
+	return func(idx *Index) {
+		idx.frequencies = ft
+	}
+}
+
+// NewIndex reads entries from r using the same NextEntry loop as CEDict,
+// and inserts each one into a new Index. It returns an error if the
+// underlying CEDict parsing fails.
+func NewIndex(r io.Reader, opts ...IndexOption) (*Index, error) {
+	c := New(r)
+	idx := &Index{root: newTrieNode()}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	for {
+		err := c.NextEntry()
+		if err == NoMoreEntries {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		idx.insert(c.Entry())
+	}
+	return idx, nil
+}
+
+// insert adds e to the trie under both its Simplified and (if different)
+// Traditional headwords, attaching a frequency count if the Index was
+// built with WithFrequencies.
+func (idx *Index) insert(e *Entry) {
+	if idx.frequencies != nil {
+		e.Frequency = idx.frequencies.Count(e.Simplified)
+	}
+	idx.insertWord(e.Simplified, e)
+	if e.Traditional != e.Simplified {
+		idx.insertWord(e.Traditional, e)
+	}
+}
+
+func (idx *Index) insertWord(word string, e *Entry) {
+	n := idx.root
+	for _, r := range word {
+		child, ok := n.children[r]
+		if !ok {
+			child = newTrieNode()
+			n.children[r] = child
+		}
+		n = child
+	}
+	n.entries = append(n.entries, e)
+}
+
+// find walks the trie to the node for word, or returns nil if word is not
+// a headword in the Index.
+func (idx *Index) find(word string) *trieNode {
+	n := idx.root
+	for _, r := range word {
+		child, ok := n.children[r]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+// Lookup returns an Entry matching word, or nil if word is not a headword
+// in the Index. If word has multiple homograph entries, the first one
+// inserted is returned; use RankedLookup to get all of them ordered by
+// frequency.
+func (idx *Index) Lookup(word string) *Entry {
+	n := idx.find(word)
+	if n == nil || len(n.entries) == 0 {
+		return nil
+	}
+	return n.entries[0]
+}
+
+// RankedLookup returns all homograph Entries for word, sorted by
+// descending Frequency. It returns nil if word is not a headword in the
+// Index.
+func (idx *Index) RankedLookup(word string) []*Entry {
+	n := idx.find(word)
+	if n == nil || len(n.entries) == 0 {
+		return nil
+	}
+	entries := make([]*Entry, len(n.entries))
+	copy(entries, n.entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Frequency > entries[j].Frequency
+	})
+	return entries
+}
+
+// Tokenize segments text into a stream of Tokens, scanning left to right
+// and greedily matching the longest dictionary headword starting at each
+// position. Runes that don't begin any known headword are emitted as their
+// own single-rune Token with a nil Entry.
+func (idx *Index) Tokenize(text string) []Token {
+	runes := []rune(text)
+	var tokens []Token
+	for i := 0; i < len(runes); {
+		n := idx.root
+		matchEnd := -1
+		var matchEntry *Entry
+		for j := i; j < len(runes); j++ {
+			child, ok := n.children[runes[j]]
+			if !ok {
+				break
+			}
+			n = child
+			if len(n.entries) > 0 {
+				matchEnd = j + 1
+				matchEntry = n.entries[0]
+			}
+		}
+		if matchEnd == -1 {
+			tokens = append(tokens, Token{Text: string(runes[i])})
+			i++
+			continue
+		}
+		tokens = append(tokens, Token{Text: string(runes[i:matchEnd]), Entry: matchEntry})
+		i = matchEnd
+	}
+	return tokens
+}
@@ -0,0 +1,283 @@
+// Copyright 2014 Herman Schaaf. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedict
+
+import (
+	"strconv"
+	"strings"
+)
+
+// initialToZhuyin maps a pinyin initial to its Zhuyin (Bopomofo) symbol.
+// The zero initial (no consonant) is represented by the empty string.
+var initialToZhuyin = map[string]string{
+	"b": "ㄅ", "p": "ㄆ", "m": "ㄇ", "f": "ㄈ",
+	"d": "ㄉ", "t": "ㄊ", "n": "ㄋ", "l": "ㄌ",
+	"g": "ㄍ", "k": "ㄎ", "h": "ㄏ",
+	"j": "ㄐ", "q": "ㄑ", "x": "ㄒ",
+	"zh": "ㄓ", "ch": "ㄔ", "sh": "ㄕ", "r": "ㄖ",
+	"z": "ㄗ", "c": "ㄘ", "s": "ㄙ",
+}
+
+// zhuyinToInitial is the inverse of initialToZhuyin.
+var zhuyinToInitial = reverseMap(initialToZhuyin)
+
+// finalToZhuyin maps a final in its canonical (fully spelled out) pinyin
+// form, e.g. "iou" rather than the abbreviated "iu", to Zhuyin.
+var finalToZhuyin = map[string]string{
+	"a": "ㄚ", "o": "ㄛ", "e": "ㄜ", "ê": "ㄝ", "er": "ㄦ",
+	"ai": "ㄞ", "ei": "ㄟ", "ao": "ㄠ", "ou": "ㄡ",
+	"an": "ㄢ", "en": "ㄣ", "ang": "ㄤ", "eng": "ㄥ",
+	"i": "ㄧ", "ia": "ㄧㄚ", "ie": "ㄧㄝ", "iao": "ㄧㄠ", "iou": "ㄧㄡ",
+	"ian": "ㄧㄢ", "in": "ㄧㄣ", "iang": "ㄧㄤ", "ing": "ㄧㄥ", "iong": "ㄩㄥ",
+	"u": "ㄨ", "ua": "ㄨㄚ", "uo": "ㄨㄛ", "uai": "ㄨㄞ", "uei": "ㄨㄟ",
+	"uan": "ㄨㄢ", "uen": "ㄨㄣ", "uang": "ㄨㄤ", "ong": "ㄨㄥ",
+	"ü": "ㄩ", "üe": "ㄩㄝ", "üan": "ㄩㄢ", "ün": "ㄩㄣ",
+}
+
+// zhuyinToFinal is the inverse of finalToZhuyin.
+var zhuyinToFinal = reverseMap(finalToZhuyin)
+
+// zeroInitialSpelling maps a canonical final to the "y"/"w" glide spelling
+// pinyin uses when the syllable has no consonant initial.
+var zeroInitialSpelling = map[string]string{
+	"i": "yi", "ia": "ya", "ie": "ye", "iao": "yao", "iou": "you",
+	"ian": "yan", "in": "yin", "iang": "yang", "ing": "ying", "iong": "yong",
+	"ü": "yu", "üe": "yue", "üan": "yuan", "ün": "yun",
+	"u": "wu", "ua": "wa", "uo": "wo", "uai": "wai", "uei": "wei",
+	"uan": "wan", "uen": "wen", "uang": "wang", "ong": "weng",
+}
+
+// glideSpellingToFinal is the inverse of zeroInitialSpelling, keyed on the
+// written glide syllable.
+var glideSpellingToFinal = reverseMap(zeroInitialSpelling)
+
+// syllabicInitials are initials that can stand alone as a full syllable
+// when followed by the written final "i" (zhi, chi, shi, ri, zi, ci, si),
+// in which case the "i" represents a buzzed/retroflexed vowel with no
+// Zhuyin glyph of its own.
+var syllabicInitials = map[string]bool{
+	"zh": true, "ch": true, "sh": true, "r": true,
+	"z": true, "c": true, "s": true,
+}
+
+func reverseMap(m map[string]string) map[string]string {
+	r := make(map[string]string, len(m))
+	for k, v := range m {
+		r[v] = k
+	}
+	return r
+}
+
+// toneZhuyinSuffix returns the Zhuyin tone glyph that follows a syllable
+// for tones 2-4. Tone 1 is left unmarked, as is conventional in Zhuyin.
+func toneZhuyinSuffix(tone int) string {
+	switch tone {
+	case 2:
+		return "ˊ"
+	case 3:
+		return "ˇ"
+	case 4:
+		return "ˋ"
+	}
+	return ""
+}
+
+// splitInitial splits s into a valid Mandarin initial and the remaining
+// final, preferring the two-letter initials zh, ch and sh. Matching is
+// case-insensitive, but the returned initial and final keep whatever case
+// s had, so a capitalized proper-noun syllable like "Lian" splits into
+// initial "L" and final "ian".
+func splitInitial(s string) (initial, final string) {
+	lower := strings.ToLower(s)
+	if len(lower) >= 2 {
+		switch lower[0:2] {
+		case "zh", "ch", "sh":
+			return s[0:2], s[2:]
+		}
+	}
+	if len(lower) >= 1 {
+		switch lower[0:1] {
+		case "b", "p", "m", "f", "d", "t", "n", "l", "g", "k", "h", "j", "q", "x", "r", "z", "c", "s":
+			return s[0:1], s[1:]
+		}
+	}
+	return "", s
+}
+
+// expandFinal rewrites a final as written in CEDICT pinyin into its
+// canonical, fully spelled out form so it can be looked up in
+// finalToZhuyin.
+func expandFinal(initial, final string) string {
+	if initial == "" {
+		if canonical, ok := glideSpellingToFinal[final]; ok {
+			return canonical
+		}
+		return final
+	}
+	if (initial == "j" || initial == "q" || initial == "x") && strings.HasPrefix(final, "u") {
+		final = "ü" + final[1:]
+	}
+	switch final {
+	case "iu":
+		return "iou"
+	case "ui":
+		return "uei"
+	case "un":
+		if initial == "j" || initial == "q" || initial == "x" {
+			return "ün"
+		}
+		return "uen"
+	}
+	return final
+}
+
+// unexpandFinal is the inverse of expandFinal: it rewrites a canonical
+// final back into the form CEDICT pinyin actually spells it with, given
+// the initial it follows.
+func unexpandFinal(initial, final string) string {
+	if initial == "" {
+		if spelling, ok := zeroInitialSpelling[final]; ok {
+			return spelling
+		}
+		return final
+	}
+	if initial == "j" || initial == "q" || initial == "x" {
+		if strings.HasPrefix(final, "ü") {
+			return "u" + final[len("ü"):]
+		}
+	}
+	switch final {
+	case "iou":
+		return "iu"
+	case "uei":
+		return "ui"
+	case "uen":
+		return "un"
+	}
+	return final
+}
+
+// ToZhuyin converts a CEDICT numbered pinyin string, e.g. "zhong1 guo2",
+// into its Zhuyin (Bopomofo) representation, e.g. "ㄓㄨㄥ ㄍㄨㄛˊ". Syllables
+// stay space-separated, since the buzzed final of syllables like zhi/chi/shi
+// has no Zhuyin glyph of its own and would otherwise make adjoining
+// syllables ambiguous to read back. Tone 1 is left unmarked, tones 2-4 are
+// marked with a trailing glyph, and the neutral tone 5 is marked with a
+// leading "˙".
+func ToZhuyin(pinyin string) string {
+	pv := strings.Replace(pinyin, "u:", "ü", -1)
+	syllables := strings.Split(pv, " ")
+
+	out := make([]string, len(syllables))
+	for i, syllable := range syllables {
+		out[i] = syllableToZhuyin(syllable)
+	}
+	return strings.Join(out, " ")
+}
+
+// syllableToZhuyin converts a single numbered pinyin syllable, e.g. "guo2",
+// to Zhuyin. Syllables that aren't recognized Mandarin pinyin (e.g. stray
+// punctuation carried over from a multi-character entry) are passed through
+// unchanged rather than emitting a partial conversion. Case doesn't affect
+// the result, since Zhuyin has no concept of capitalization: "Lian2" and
+// "lian2" both convert to the same glyphs.
+func syllableToZhuyin(syllable string) string {
+	noTone, tone := extractTone(syllable)
+	initial, final := splitInitial(noTone)
+	initial, final = strings.ToLower(initial), strings.ToLower(final)
+	final = expandFinal(initial, final)
+
+	initialGlyph, okInitial := initialToZhuyin[initial]
+	okInitial = okInitial || initial == ""
+	finalGlyph, okFinal := finalToZhuyin[final]
+	if final == "i" && syllabicInitials[initial] {
+		finalGlyph, okFinal = "", true
+	}
+	if !okInitial || !okFinal {
+		return syllable
+	}
+
+	if tone == 5 {
+		return "˙" + initialGlyph + finalGlyph
+	}
+	return initialGlyph + finalGlyph + toneZhuyinSuffix(tone)
+}
+
+// ZhuyinToPinyin converts a space-separated Zhuyin (Bopomofo) string back
+// into space-separated numbered pinyin, e.g. "ㄓㄨㄥ ㄍㄨㄛˊ" becomes
+// "zhong1 guo2". It is the inverse of ToZhuyin, except that the vowel ü is
+// written as "ü" (e.g. "nü3") rather than CEDICT's "u:".
+func ZhuyinToPinyin(zhuyin string) string {
+	syllables := strings.Split(zhuyin, " ")
+	out := make([]string, len(syllables))
+	for i, syllable := range syllables {
+		out[i] = zhuyinSyllableToPinyin(syllable)
+	}
+	return strings.Join(out, " ")
+}
+
+// zhuyinSyllableToPinyin converts a single Zhuyin syllable back into
+// numbered pinyin. Input that doesn't parse as a complete Zhuyin syllable
+// is returned unchanged.
+func zhuyinSyllableToPinyin(syllable string) string {
+	runes := []rune(syllable)
+	i := 0
+
+	tone := 1
+	neutral := false
+	if i < len(runes) && runes[i] == '˙' {
+		neutral = true
+		tone = 5
+		i++
+	}
+
+	initial := ""
+	if i < len(runes) {
+		if ini, ok := zhuyinToInitial[string(runes[i])]; ok {
+			initial = ini
+			i++
+		}
+	}
+
+	final := ""
+	if i+1 < len(runes) {
+		if f, ok := zhuyinToFinal[string(runes[i:i+2])]; ok {
+			final = f
+			i += 2
+		}
+	}
+	if final == "" && i < len(runes) {
+		if f, ok := zhuyinToFinal[string(runes[i])]; ok {
+			final = f
+			i++
+		}
+	}
+	if final == "" && syllabicInitials[initial] {
+		final = "i"
+	}
+	if final == "" {
+		return syllable
+	}
+
+	if !neutral && i < len(runes) {
+		switch runes[i] {
+		case 'ˊ':
+			tone = 2
+			i++
+		case 'ˇ':
+			tone = 3
+			i++
+		case 'ˋ':
+			tone = 4
+			i++
+		}
+	}
+
+	if i != len(runes) {
+		// Not a recognized Zhuyin syllable; leave it as-is.
+		return syllable
+	}
+	return initial + unexpandFinal(initial, final) + strconv.Itoa(tone)
+}